@@ -0,0 +1,42 @@
+package stats
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"context"
+	"time"
+
+	"github.com/varun06/stats/internal/conn"
+)
+
+//connStatsInterval is how often endpoint connection health (reconnects, dropped
+//writes, last error) is reported back through the broker by StartStatsd/StartGraphite
+const connStatsInterval = 10 * time.Second
+
+//ReportConnStats periodically reports a conn.Manager's reconnect count, dropped datum
+//count and time since its last error back through the broker itself, under
+//"<name>.reconnects", "<name>.dropped" and "<name>.last_error_seconds_ago", so a
+//statsd/graphite endpoint's own connection health can be alerted on like any other stat.
+//It runs until ctx is cancelled.
+func ReportConnStats(ctx context.Context, broker Broker, name string, m *conn.Manager, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s := m.Stats()
+
+			broker.Gauge(name+".reconnects", int(s.Reconnects))
+			broker.Gauge(name+".dropped", int(s.Dropped))
+
+			if !s.LastErrTime.IsZero() {
+				broker.Gauge(name+".last_error_seconds_ago", int(time.Since(s.LastErrTime).Seconds()))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
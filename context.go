@@ -7,7 +7,7 @@ import (
 	"time"
 
 	"github.com/MediaMath/govent/graphite"
-	"gopkg.in/alexcesaro/statsd.v2"
+	influxdb "github.com/influxdata/influxdb1-client/v2"
 
 	"golang.org/x/net/context"
 )
@@ -22,6 +22,8 @@ const (
 	graphiteUserKey
 	graphitePasswordKey
 	graphiteVerboseKey
+	influxURLKey
+	influxDatabaseKey
 )
 
 //SetPrefix sets the stats prefix
@@ -49,6 +51,16 @@ func SetGraphite(ctx context.Context, url, user, password string, verbose bool)
 	return ctx
 }
 
+//SetInfluxURL sets the InfluxDB server address
+func SetInfluxURL(ctx context.Context, url string) context.Context {
+	return context.WithValue(ctx, influxURLKey, url)
+}
+
+//SetInfluxDatabase sets the InfluxDB database to write points to
+func SetInfluxDatabase(ctx context.Context, database string) context.Context {
+	return context.WithValue(ctx, influxDatabaseKey, database)
+}
+
 //SetRuntimeInterval sets the runtime stats collector interval
 func SetRuntimeInterval(ctx context.Context, interval time.Duration) context.Context {
 	return context.WithValue(ctx, runtimeIntervalKey, interval)
@@ -62,30 +74,27 @@ func HasStats(ctx context.Context) (hasStatsdURL bool, hasGraphiteURL bool) {
 	return statsdURL != "", graphiteURL != ""
 }
 
-//RegisterStatsContext starts statsd and graphite based on the context
-func RegisterStatsContext(ctx context.Context) error {
+//RegisterStatsContext starts statsd and graphite based on the context and returns a
+//Provider backed by DefaultBroker, so applications can thread it down to their
+//components instead of relying on the package-level DefaultBroker directly.
+func RegisterStatsContext(ctx context.Context) (Provider, error) {
 	prefix := GetPrefix(ctx)
 	if prefix == "" {
-		return fmt.Errorf("No prefix not starting stats consumers")
+		return nil, fmt.Errorf("No prefix not starting stats consumers")
 	}
 
 	statsdURL := getString(ctx, statsdURLKey, "")
 	if statsdURL == "" {
-		return fmt.Errorf("No statsd URL not starting stats consumers")
+		return nil, fmt.Errorf("No statsd URL not starting stats consumers")
 	}
 
 	graphiteURL := getString(ctx, graphiteURLKey, "")
 	if graphiteURL == "" {
-		return fmt.Errorf("No graphite URL not starting stats consumers")
+		return nil, fmt.Errorf("No graphite URL not starting stats consumers")
 	}
 
 	log.Printf("Register statsd: %v %v", statsdURL, prefix)
-	s, err := statsd.New(statsd.Address(statsdURL), statsd.Prefix(prefix))
-	if err != nil {
-		return err
-	}
-
-	go StartStatsd(ctx, DefaultBroker, s)
+	go StartStatsd(ctx, DefaultBroker, statsdURL, prefix)
 
 	graphiteUser := getString(ctx, graphiteUserKey, "")
 	graphitePassword := getString(ctx, graphitePasswordKey, "")
@@ -103,7 +112,19 @@ func RegisterStatsContext(ctx context.Context) error {
 	log.Printf("Starting graphite %v %v", govent.Username, govent.Addr)
 	go StartGraphite(ctx, DefaultBroker, govent)
 
-	return nil
+	influxURL := getString(ctx, influxURLKey, "")
+	influxDatabase := getString(ctx, influxDatabaseKey, "")
+	if influxURL != "" && influxDatabase != "" {
+		influxClient, err := influxdb.NewHTTPClient(influxdb.HTTPConfig{Addr: influxURL})
+		if err != nil {
+			return nil, err
+		}
+
+		log.Printf("Starting influxdb: %v %v", influxURL, influxDatabase)
+		go StartInfluxDB(ctx, DefaultBroker, influxClient, influxDatabase, time.Second*10)
+	}
+
+	return NewBrokerProvider(DefaultBroker), nil
 }
 
 //RegisterRuntimeStatsContext starts runtime stats reporting based on the context
@@ -0,0 +1,12 @@
+package stats
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+//defaultBrokerBufferSize is the channel buffer size used for DefaultBroker
+const defaultBrokerBufferSize = 1000
+
+//DefaultBroker is the package level Broker used by callers who don't thread their own
+//Broker through explicitly. It is started at package initialization time.
+var DefaultBroker = StartBroker(defaultBrokerBufferSize)
@@ -0,0 +1,149 @@
+package stats
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/MediaMath/govent/graphite"
+	"github.com/varun06/stats/internal/conn"
+)
+
+//graphiteQueueSize bounds how many pending graphite events are buffered while publishing
+//is backing off after a failure before new ones start getting dropped
+const graphiteQueueSize = 1000
+
+//graphiteHistogramWindow is how often each stat's WindowedHistogram rotates and its
+//derived quantile stats are flushed
+const graphiteHistogramWindow = time.Minute
+
+//graphiteHistogramWindows is the number of windows each stat's WindowedHistogram keeps
+//live at once, i.e. how many graphiteHistogramWindow periods a quantile is computed over
+const graphiteHistogramWindows = 5
+
+//graphiteConn adapts g.Publish into a conn.Manager Conn, so a failing publish is followed
+//by the same backoff delay as a dropped socket rather than wedging the endpoint.
+//There is no persistent connection to hold open, so Close is a no-op; Manager still
+//counts a reconnect after every failed Write, and the event whose Publish failed is
+//dropped rather than re-published. Queued payloads are JSON encoded
+//*graphite.Event values rather than raw bytes, since Publish is the only thing this
+//dependency exposes to send data - there is no plaintext metrics protocol to POST to.
+type graphiteConn struct {
+	g *graphite.Graphite
+}
+
+func (c *graphiteConn) Write(p []byte) (int, error) {
+	var e graphite.Event
+	if err := json.Unmarshal(p, &e); err != nil {
+		return 0, err
+	}
+
+	if err := c.g.Publish(&e); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (c *graphiteConn) Close() error { return nil }
+
+//StartGraphite registers an Endpoint that renders count/gauge/timing datums as graphite
+//events and publishes them through g, and forwards any event sent via
+//Broker.GraphiteEvent/Broker.Event as-is. Publishes go through a conn.Manager so a
+//failing one is dropped and followed by a backoff delay instead of wedging the endpoint. g.Prefix is
+//applied by Publish itself, so callers don't need to prepend it to stat names.
+func StartGraphite(ctx context.Context, broker Broker, g *graphite.Graphite) error {
+	dial := func() (conn.Conn, error) {
+		return &graphiteConn{g: g}, nil
+	}
+
+	m := conn.NewManager(dial, graphiteQueueSize, nil)
+	go ReportConnStats(ctx, broker, "graphite", m, connStatsInterval)
+
+	return broker.RegisterEndpoint(func(events <-chan interface{}) {
+		runGraphite(ctx, m, events)
+	})
+}
+
+//runGraphite publishes count/gauge/event datums immediately, but buffers timing datums
+//into a per-name WindowedHistogram and only publishes the derived
+//.p50/.p90/.p95/.p99/.min/.max/.avg/.count stats once per graphiteHistogramWindow tick,
+//rather than an event per sample.
+func runGraphite(ctx context.Context, m *conn.Manager, events <-chan interface{}) {
+	defer m.Close()
+
+	histograms := make(map[string]*WindowedHistogram)
+	ticker := time.NewTicker(graphiteHistogramWindow)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case act, ok := <-events:
+			if !ok {
+				return
+			}
+
+			switch a := act.(type) {
+			case *timing:
+				timingHistogram(histograms, a.Name).Record(a.Value)
+			case *event:
+				writeGraphiteEvent(m, a.Event)
+			default:
+				if e := graphiteEvent(act); e != nil {
+					writeGraphiteEvent(m, e)
+				}
+			}
+		case <-ticker.C:
+			for name, h := range histograms {
+				for _, stat := range h.QuantileStats(name) {
+					writeGraphiteEvent(m, metricEvent(stat.Name, stat.Value))
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func timingHistogram(histograms map[string]*WindowedHistogram, name string) *WindowedHistogram {
+	h, ok := histograms[name]
+	if !ok {
+		h = NewWindowedHistogram(graphiteHistogramWindows, 0)
+		histograms[name] = h
+	}
+
+	return h
+}
+
+func graphiteEvent(act interface{}) *graphite.Event {
+	switch a := act.(type) {
+	case *count:
+		return metricEvent(a.Name, float64(a.Value))
+	case *gauge:
+		return metricEvent(a.Name, float64(a.Value))
+	case *biggauge:
+		return metricEvent(a.Name, float64(a.Value))
+	default:
+		return nil
+	}
+}
+
+//metricEvent wraps a single count/gauge/derived-quantile value as a graphite.Event,
+//since Publish is the only thing *graphite.Graphite exposes for sending data.
+func metricEvent(name string, value float64) *graphite.Event {
+	return graphite.NewTaggedEvent(name, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func writeGraphiteEvent(m *conn.Manager, e *graphite.Event) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	m.Write(b)
+}
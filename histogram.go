@@ -0,0 +1,166 @@
+package stats
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+const (
+	defaultHistogramWindows   = 5
+	defaultHistogramSampleCap = 1000
+)
+
+//sample is a fixed capacity, reservoir sampled set of observations for a single window
+type sample struct {
+	values []int
+	seen   int
+	cap    int
+}
+
+func newSample(cap int) *sample {
+	return &sample{values: make([]int, 0, cap), cap: cap}
+}
+
+func (s *sample) record(value int) {
+	s.seen++
+
+	if len(s.values) < s.cap {
+		s.values = append(s.values, value)
+		return
+	}
+
+	if i := rand.Intn(s.seen); i < s.cap {
+		s.values[i] = value
+	}
+}
+
+//WindowedHistogram maintains a ring of windowed reservoirs (e.g. one per minute) so that
+//Snapshot can report quantiles over a trailing period without retaining every observation
+//forever. This mirrors the windowed-histogram pattern used by go-kit's graphite Emitter.
+type WindowedHistogram struct {
+	mu        sync.Mutex
+	windows   []*sample
+	cursor    int
+	sampleCap int
+}
+
+//NewWindowedHistogram creates a WindowedHistogram with windows sub-histograms, each capped
+//at sampleCap reservoir-sampled observations. windows <= 0 defaults to 5, sampleCap <= 0
+//defaults to 1000.
+func NewWindowedHistogram(windows, sampleCap int) *WindowedHistogram {
+	if windows <= 0 {
+		windows = defaultHistogramWindows
+	}
+
+	if sampleCap <= 0 {
+		sampleCap = defaultHistogramSampleCap
+	}
+
+	h := &WindowedHistogram{windows: make([]*sample, windows), sampleCap: sampleCap}
+	for i := range h.windows {
+		h.windows[i] = newSample(sampleCap)
+	}
+
+	return h
+}
+
+//Record adds value to the current window
+func (h *WindowedHistogram) Record(value int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.windows[h.cursor].record(value)
+}
+
+//Tick rotates the oldest window out in favor of a fresh one, so it no longer contributes
+//to future snapshots. Call this once per window duration (e.g. every minute for 1 minute
+//windows).
+func (h *WindowedHistogram) Tick() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.cursor = (h.cursor + 1) % len(h.windows)
+	h.windows[h.cursor] = newSample(h.sampleCap)
+}
+
+//Quantiles is a snapshot of a WindowedHistogram's live windows at flush time
+type Quantiles struct {
+	P50, P90, P95, P99 float64
+	Min, Max, Avg      float64
+	Count              int
+}
+
+//Snapshot merges the samples across all live windows and computes quantile stats. It
+//returns the zero Quantiles if no observations have been recorded.
+func (h *WindowedHistogram) Snapshot() Quantiles {
+	h.mu.Lock()
+	merged := make([]int, 0)
+	for _, w := range h.windows {
+		merged = append(merged, w.values...)
+	}
+	h.mu.Unlock()
+
+	if len(merged) == 0 {
+		return Quantiles{}
+	}
+
+	sort.Ints(merged)
+
+	sum := 0
+	for _, v := range merged {
+		sum += v
+	}
+
+	return Quantiles{
+		P50:   percentile(merged, 0.50),
+		P90:   percentile(merged, 0.90),
+		P95:   percentile(merged, 0.95),
+		P99:   percentile(merged, 0.99),
+		Min:   float64(merged[0]),
+		Max:   float64(merged[len(merged)-1]),
+		Avg:   float64(sum) / float64(len(merged)),
+		Count: len(merged),
+	}
+}
+
+func percentile(sorted []int, p float64) float64 {
+	if len(sorted) == 1 {
+		return float64(sorted[0])
+	}
+
+	idx := int(p * float64(len(sorted)-1))
+	return float64(sorted[idx])
+}
+
+//HistogramStat is one derived stat produced by QuantileStats, e.g. {Name: "foo.p50",
+//Value: 12}.
+type HistogramStat struct {
+	Name  string
+	Value float64
+}
+
+//QuantileStats snapshots h, rotates its window via Tick, and returns the derived
+//.p50/.p90/.p95/.p99/.min/.max/.avg/.count stats with name prepended to each suffix.
+//Endpoints that consume Histogram/Timing datums call this once per flush interval and
+//write the result out however their wire protocol requires, so the list of derived
+//stats only has to be maintained in one place.
+func (h *WindowedHistogram) QuantileStats(name string) []HistogramStat {
+	q := h.Snapshot()
+	h.Tick()
+
+	return []HistogramStat{
+		{name + ".p50", q.P50},
+		{name + ".p90", q.P90},
+		{name + ".p95", q.P95},
+		{name + ".p99", q.P99},
+		{name + ".min", q.Min},
+		{name + ".max", q.Max},
+		{name + ".avg", q.Avg},
+		{name + ".count", float64(q.Count)},
+	}
+}
@@ -0,0 +1,80 @@
+package stats
+
+import "testing"
+
+func TestWindowedHistogramSnapshotEmpty(t *testing.T) {
+	h := NewWindowedHistogram(0, 0)
+
+	q := h.Snapshot()
+	if q.Count != 0 {
+		t.Fatalf("expected zero Quantiles for an empty histogram, got %+v", q)
+	}
+}
+
+func TestWindowedHistogramSnapshotComputesQuantiles(t *testing.T) {
+	h := NewWindowedHistogram(1, 0)
+
+	for i := 1; i <= 100; i++ {
+		h.Record(i)
+	}
+
+	q := h.Snapshot()
+
+	if q.Count != 100 {
+		t.Fatalf("expected Count 100, got %d", q.Count)
+	}
+
+	if q.Min != 1 {
+		t.Fatalf("expected Min 1, got %v", q.Min)
+	}
+
+	if q.Max != 100 {
+		t.Fatalf("expected Max 100, got %v", q.Max)
+	}
+
+	if q.Avg != 50.5 {
+		t.Fatalf("expected Avg 50.5, got %v", q.Avg)
+	}
+
+	if q.P50 < 45 || q.P50 > 55 {
+		t.Fatalf("expected P50 near 50, got %v", q.P50)
+	}
+
+	if q.P99 < 95 {
+		t.Fatalf("expected P99 near the top of the range, got %v", q.P99)
+	}
+}
+
+func TestWindowedHistogramTickDropsOldestWindow(t *testing.T) {
+	h := NewWindowedHistogram(2, 0)
+
+	h.Record(1)
+	h.Tick()
+	h.Record(2)
+
+	q := h.Snapshot()
+	if q.Count != 2 {
+		t.Fatalf("expected both live windows represented, got count %d", q.Count)
+	}
+
+	h.Tick()
+	h.Tick()
+
+	q = h.Snapshot()
+	if q.Count != 0 {
+		t.Fatalf("expected rotated-out windows to stop contributing, got count %d", q.Count)
+	}
+}
+
+func TestWindowedHistogramReservoirCapsMemory(t *testing.T) {
+	h := NewWindowedHistogram(1, 10)
+
+	for i := 0; i < 1000; i++ {
+		h.Record(i)
+	}
+
+	q := h.Snapshot()
+	if q.Count != 10 {
+		t.Fatalf("expected the reservoir to cap at 10 samples, got %d", q.Count)
+	}
+}
@@ -0,0 +1,106 @@
+package stats
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"context"
+	"log"
+	"time"
+
+	influxdb "github.com/influxdata/influxdb1-client/v2"
+)
+
+//influxBatchSize is the number of points buffered before a flush is forced, independent of flushInterval
+const influxBatchSize = 100
+
+//StartInfluxDB registers an Endpoint that batches count/gauge/timing datums into InfluxDB
+//line protocol points and writes them to client in batches of influxBatchSize or on every
+//flushInterval tick, whichever comes first. Any Tags on the datum become point tags. ctx
+//cancellation flushes the current batch and stops the endpoint.
+func StartInfluxDB(ctx context.Context, broker Broker, client influxdb.Client, database string, flushInterval time.Duration) error {
+	return broker.RegisterEndpoint(func(events <-chan interface{}) {
+		runInfluxDB(ctx, client, database, flushInterval, events)
+	})
+}
+
+func runInfluxDB(ctx context.Context, client influxdb.Client, database string, flushInterval time.Duration, events <-chan interface{}) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	bp := newInfluxBatch(database)
+
+	for {
+		select {
+		case act, ok := <-events:
+			if !ok {
+				flushInflux(client, bp)
+				return
+			}
+
+			if p := influxPoint(act); p != nil {
+				bp.AddPoint(p)
+
+				if len(bp.Points()) >= influxBatchSize {
+					bp = flushInflux(client, bp)
+				}
+			}
+		case <-ticker.C:
+			bp = flushInflux(client, bp)
+		case <-ctx.Done():
+			flushInflux(client, bp)
+			return
+		}
+	}
+}
+
+func newInfluxBatch(database string) influxdb.BatchPoints {
+	bp, err := influxdb.NewBatchPoints(influxdb.BatchPointsConfig{Database: database})
+	if err != nil {
+		log.Printf("influxdb: unable to create batch: %v", err)
+	}
+
+	return bp
+}
+
+func flushInflux(client influxdb.Client, bp influxdb.BatchPoints) influxdb.BatchPoints {
+	if bp == nil || len(bp.Points()) == 0 {
+		return bp
+	}
+
+	if err := client.Write(bp); err != nil {
+		log.Printf("influxdb: write failed: %v", err)
+	}
+
+	return newInfluxBatch(bp.Database())
+}
+
+func influxPoint(act interface{}) *influxdb.Point {
+	switch a := act.(type) {
+	case *count:
+		return newInfluxPoint(a.Name, a.Tags, a.Value)
+	case *gauge:
+		return newInfluxPoint(a.Name, a.Tags, a.Value)
+	case *biggauge:
+		return newInfluxPoint(a.Name, nil, a.Value)
+	case *timing:
+		//Deliberately kept as a raw per-sample point, unlike the statsd/graphite endpoints
+		//which pre-aggregate through a WindowedHistogram: InfluxDB can compute percentiles
+		//at query time (PERCENTILE()) over the raw samples, so pre-aggregating here would
+		//only throw away precision for no benefit.
+		return newInfluxPoint(a.Name, a.Tags, a.Value)
+	default:
+		return nil
+	}
+}
+
+func newInfluxPoint(measurement string, tags map[string]string, value interface{}) *influxdb.Point {
+	p, err := influxdb.NewPoint(measurement, tags, map[string]interface{}{"value": value}, time.Now())
+	if err != nil {
+		log.Printf("influxdb: unable to create point for %v: %v", measurement, err)
+		return nil
+	}
+
+	return p
+}
@@ -0,0 +1,198 @@
+//Package conn provides a resilient connection holder that reconnects on write failure,
+//inspired by go-kit's util/conn.Manager.
+package conn
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+//Conn is anything a Manager can write to and must close on failure or shutdown. A
+//net.Conn satisfies this directly; an HTTP based sink can implement it with Write
+//performing a request and Close as a no-op.
+type Conn interface {
+	Write(p []byte) (int, error)
+	Close() error
+}
+
+//Dialer establishes a new connection to a remote endpoint
+type Dialer func() (Conn, error)
+
+//BackoffFunc computes the delay to wait before the nth reconnect attempt
+type BackoffFunc func(attempt int) time.Duration
+
+//Manager holds a single Conn, reconnecting it on write failure using a backoff
+//schedule. Writes are queued up to a bounded size and dropped while disconnected
+//once that bound is reached, so a slow reconnect degrades rather than blocks callers.
+type Manager struct {
+	dial    Dialer
+	backoff BackoffFunc
+	queue   chan []byte
+
+	mu          sync.Mutex
+	conn        Conn
+	reconnects  uint64
+	dropped     uint64
+	lastErr     error
+	lastErrTime time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+//NewManager creates a Manager that dials with d, buffering up to queueSize pending
+//writes while disconnected. backoff is consulted between reconnect attempts; if nil,
+//ExponentialBackoff(100ms, 30s) is used.
+func NewManager(d Dialer, queueSize int, backoff BackoffFunc) *Manager {
+	if backoff == nil {
+		backoff = ExponentialBackoff(100*time.Millisecond, 30*time.Second)
+	}
+
+	m := &Manager{
+		dial:    d,
+		backoff: backoff,
+		queue:   make(chan []byte, queueSize),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go m.loop()
+
+	return m
+}
+
+//Write enqueues b to be written to the underlying connection once it is available.
+//If the queue is full the write is dropped and counted in Stats().
+func (m *Manager) Write(b []byte) error {
+	cp := append([]byte(nil), b...)
+
+	select {
+	case m.queue <- cp:
+	default:
+		m.mu.Lock()
+		m.dropped++
+		m.mu.Unlock()
+	}
+
+	return nil
+}
+
+//Stats is a snapshot of a Manager's health counters
+type Stats struct {
+	Reconnects  uint64
+	Dropped     uint64
+	LastError   error
+	LastErrTime time.Time
+}
+
+//Stats returns a snapshot of the Manager's reconnect/drop/error counters
+func (m *Manager) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return Stats{Reconnects: m.reconnects, Dropped: m.dropped, LastError: m.lastErr, LastErrTime: m.lastErrTime}
+}
+
+//Close stops the reconnect loop and closes the underlying connection, if any
+func (m *Manager) Close() error {
+	close(m.stop)
+	<-m.done
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.conn != nil {
+		return m.conn.Close()
+	}
+
+	return nil
+}
+
+func (m *Manager) loop() {
+	defer close(m.done)
+
+	attempt := 0
+	for {
+		c, err := m.dial()
+		if err != nil {
+			m.recordError(err)
+			attempt++
+
+			select {
+			case <-time.After(m.backoff(attempt)):
+				continue
+			case <-m.stop:
+				return
+			}
+		}
+
+		attempt = 0
+		m.mu.Lock()
+		m.conn = c
+		m.mu.Unlock()
+
+		if !m.drain(c) {
+			return
+		}
+
+		//drain only returns true after a failed write; back off before redialing
+		attempt++
+		select {
+		case <-time.After(m.backoff(attempt)):
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+//drain writes queued data to c until a write fails, triggering a reconnect, or the
+//Manager is closed, in which case it returns false
+func (m *Manager) drain(c Conn) bool {
+	for {
+		select {
+		case b := <-m.queue:
+			if _, err := c.Write(b); err != nil {
+				m.recordError(err)
+				m.reconnect(c)
+				return true
+			}
+		case <-m.stop:
+			c.Close()
+			return false
+		}
+	}
+}
+
+//reconnect is only ever called from drain after a failed Write, so the payload that
+//triggered it is lost along with the connection; count both.
+func (m *Manager) reconnect(c Conn) {
+	c.Close()
+
+	m.mu.Lock()
+	m.reconnects++
+	m.dropped++
+	m.conn = nil
+	m.mu.Unlock()
+}
+
+func (m *Manager) recordError(err error) {
+	m.mu.Lock()
+	m.lastErr = err
+	m.lastErrTime = time.Now()
+	m.mu.Unlock()
+}
+
+//ExponentialBackoff returns a BackoffFunc that doubles the delay on every attempt,
+//capped at max, with up to 50% jitter added to avoid endpoints reconnecting in lockstep
+func ExponentialBackoff(min, max time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		d := min << uint(attempt-1)
+		if d <= 0 || d > max {
+			d = max
+		}
+
+		jitter := time.Duration(rand.Int63n(int64(d)/2 + 1))
+		return d + jitter
+	}
+}
@@ -0,0 +1,114 @@
+package conn
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoffCapsAtMax(t *testing.T) {
+	backoff := ExponentialBackoff(10*time.Millisecond, 100*time.Millisecond)
+
+	for attempt := 1; attempt <= 20; attempt++ {
+		d := backoff(attempt)
+		if d < 10*time.Millisecond || d > 150*time.Millisecond {
+			t.Fatalf("attempt %d: backoff %v outside expected range", attempt, d)
+		}
+	}
+}
+
+func TestExponentialBackoffGrows(t *testing.T) {
+	backoff := ExponentialBackoff(time.Millisecond, time.Hour)
+
+	if d2, d6 := backoff(2), backoff(6); d2 >= d6 {
+		t.Fatalf("expected backoff to grow with attempt count, got %v then %v", d2, d6)
+	}
+}
+
+type fakeConn struct {
+	mu      sync.Mutex
+	writes  [][]byte
+	failing bool
+}
+
+func (f *fakeConn) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failing {
+		return 0, errors.New("write failed")
+	}
+
+	f.writes = append(f.writes, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func (f *fakeConn) Close() error { return nil }
+
+func TestManagerWritesThroughDialedConn(t *testing.T) {
+	c := &fakeConn{}
+	m := NewManager(func() (Conn, error) { return c, nil }, 10, ExponentialBackoff(time.Millisecond, time.Millisecond))
+	defer m.Close()
+
+	m.Write([]byte("hello"))
+
+	deadline := time.After(time.Second)
+	for {
+		c.mu.Lock()
+		n := len(c.writes)
+		c.mu.Unlock()
+
+		if n == 1 {
+			break
+		}
+
+		select {
+		case <-deadline:
+			t.Fatal("write was never delivered to the dialed conn")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestManagerDropsWhenQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	m := NewManager(func() (Conn, error) {
+		<-block
+		return &fakeConn{}, nil
+	}, 1, ExponentialBackoff(time.Millisecond, time.Millisecond))
+	defer func() {
+		close(block)
+		m.Close()
+	}()
+
+	m.Write([]byte("a"))
+	m.Write([]byte("b"))
+	m.Write([]byte("c"))
+
+	if dropped := m.Stats().Dropped; dropped == 0 {
+		t.Fatalf("expected some writes to be dropped while disconnected, got %d", dropped)
+	}
+}
+
+func TestManagerDropsOnFailedWrite(t *testing.T) {
+	c := &fakeConn{failing: true}
+	m := NewManager(func() (Conn, error) { return c, nil }, 10, ExponentialBackoff(time.Millisecond, time.Millisecond))
+	defer m.Close()
+
+	m.Write([]byte("hello"))
+
+	deadline := time.After(time.Second)
+	for {
+		s := m.Stats()
+		if s.Reconnects >= 1 && s.Dropped >= 1 {
+			break
+		}
+
+		select {
+		case <-deadline:
+			t.Fatalf("expected a failed write to count as both a reconnect and a drop, got %+v", s)
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
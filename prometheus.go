@@ -0,0 +1,142 @@
+package stats
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"net/http"
+	"regexp"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+//invalidMetricChars matches everything Prometheus metric names can't contain, e.g. the
+//"." that separates segments of every other endpoint's dotted stat names
+var invalidMetricChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+
+//sanitizeMetricName translates a dotted stat name like "foo.bar.p50" into a valid
+//Prometheus metric name ("foo_bar_p50"); MustRegister panics on the former.
+func sanitizeMetricName(name string) string {
+	sanitized := invalidMetricChars.ReplaceAllString(name, "_")
+
+	if sanitized == "" || (sanitized[0] >= '0' && sanitized[0] <= '9') {
+		sanitized = "_" + sanitized
+	}
+
+	return sanitized
+}
+
+//PrometheusEndpoint is a pull based Endpoint that mirrors the push based statsd/graphite
+//endpoints onto Prometheus collectors so they can be scraped from an HTTP handler instead
+//of shipped out to a remote sink.
+type PrometheusEndpoint struct {
+	registry *prometheus.Registry
+	buckets  []float64
+
+	mu         sync.Mutex
+	counters   map[string]*prometheus.CounterVec
+	gauges     map[string]*prometheus.GaugeVec
+	histograms map[string]*prometheus.HistogramVec
+}
+
+//NewPrometheusEndpoint builds a PrometheusEndpoint that registers collectors with the
+//supplied registry as new stat names are observed. If buckets is nil prometheus.DefBuckets
+//is used for any Timing derived histograms.
+func NewPrometheusEndpoint(registry *prometheus.Registry, buckets []float64) *PrometheusEndpoint {
+	if buckets == nil {
+		buckets = prometheus.DefBuckets
+	}
+
+	return &PrometheusEndpoint{
+		registry:   registry,
+		buckets:    buckets,
+		counters:   make(map[string]*prometheus.CounterVec),
+		gauges:     make(map[string]*prometheus.GaugeVec),
+		histograms: make(map[string]*prometheus.HistogramVec),
+	}
+}
+
+//Endpoint returns the Endpoint function to register with a Broker
+func (p *PrometheusEndpoint) Endpoint() Endpoint {
+	return p.run
+}
+
+//Handler returns an http.Handler that serves the collected metrics in the Prometheus
+//exposition format, suitable for registering on a caller's own mux (e.g. at "/metrics")
+func (p *PrometheusEndpoint) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
+
+func (p *PrometheusEndpoint) run(events <-chan interface{}) {
+	for act := range events {
+		switch a := act.(type) {
+		case *count:
+			p.counterFor(a.Name).WithLabelValues().Add(float64(a.Value))
+		case *gauge:
+			p.gaugeFor(a.Name).WithLabelValues().Set(float64(a.Value))
+		case *biggauge:
+			p.gaugeFor(a.Name).WithLabelValues().Set(float64(a.Value))
+		case *timing:
+			p.histogramFor(a.Name).WithLabelValues().Observe(float64(a.Value))
+		}
+	}
+}
+
+//counterFor returns the CounterVec registered for name, creating it if necessary. The
+//cache is keyed by the sanitized name, not name itself, since that's what it was
+//registered under; otherwise two names that sanitize to the same string (e.g. "foo.bar"
+//and "foo_bar") would both miss the cache and the second MustRegister would panic.
+func (p *PrometheusEndpoint) counterFor(name string) *prometheus.CounterVec {
+	sanitized := sanitizeMetricName(name)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, ok := p.counters[sanitized]
+	if !ok {
+		c = prometheus.NewCounterVec(prometheus.CounterOpts{Name: sanitized, Help: name}, nil)
+		p.registry.MustRegister(c)
+		p.counters[sanitized] = c
+	}
+
+	return c
+}
+
+//gaugeFor returns the GaugeVec registered for name, creating it if necessary; see
+//counterFor for why the cache is keyed by the sanitized name.
+func (p *PrometheusEndpoint) gaugeFor(name string) *prometheus.GaugeVec {
+	sanitized := sanitizeMetricName(name)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	g, ok := p.gauges[sanitized]
+	if !ok {
+		g = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: sanitized, Help: name}, nil)
+		p.registry.MustRegister(g)
+		p.gauges[sanitized] = g
+	}
+
+	return g
+}
+
+//histogramFor returns the HistogramVec registered for name, creating it if necessary;
+//see counterFor for why the cache is keyed by the sanitized name.
+func (p *PrometheusEndpoint) histogramFor(name string) *prometheus.HistogramVec {
+	sanitized := sanitizeMetricName(name)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h, ok := p.histograms[sanitized]
+	if !ok {
+		h = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: sanitized, Help: name, Buckets: p.buckets}, nil)
+		p.registry.MustRegister(h)
+		p.histograms[sanitized] = h
+	}
+
+	return h
+}
@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestSanitizeMetricName(t *testing.T) {
+	cases := map[string]string{
+		"foo":               "foo",
+		"foo.bar":           "foo_bar",
+		"foo.bar.p50":       "foo_bar_p50",
+		"foo-bar":           "foo_bar",
+		"2xx.count":         "_2xx_count",
+		"already_valid:foo": "already_valid:foo",
+	}
+
+	for in, want := range cases {
+		if got := sanitizeMetricName(in); got != want {
+			t.Errorf("sanitizeMetricName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPrometheusEndpointSanitizesDottedNames(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	endpoint := NewPrometheusEndpoint(registry, nil)
+
+	events := make(chan interface{}, 1)
+	events <- &count{Name: "foo.bar.reconnects", Value: 1}
+	close(events)
+
+	endpoint.run(events)
+
+	metrics, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+
+	var found bool
+	for _, m := range metrics {
+		if m.GetName() == "foo_bar_reconnects" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatalf("expected a sanitized metric named foo_bar_reconnects, got %v", metrics)
+	}
+}
+
+func TestPrometheusEndpointCachesCollectorForRepeatedName(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	endpoint := NewPrometheusEndpoint(registry, nil)
+
+	first := endpoint.counterFor("requests.total")
+	second := endpoint.counterFor("requests.total")
+
+	if first != second {
+		t.Fatal("expected repeated calls for the same name to return the cached collector")
+	}
+}
+
+func TestPrometheusEndpointCachesCollectorBySanitizedName(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	endpoint := NewPrometheusEndpoint(registry, nil)
+
+	// foo.bar and foo_bar both sanitize to "foo_bar"; the second call must hit the
+	// cache instead of calling MustRegister again, which would panic on the collision.
+	first := endpoint.counterFor("foo.bar")
+	second := endpoint.counterFor("foo_bar")
+
+	if first != second {
+		t.Fatal("expected names that sanitize to the same string to share a collector")
+	}
+}
@@ -0,0 +1,395 @@
+package stats
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"context"
+	"sync"
+
+	"github.com/MediaMath/govent/graphite"
+)
+
+//Counter is a named metric that can be incremented
+type Counter interface {
+	With(label, value string) Counter
+	Add(delta float64)
+}
+
+//Gauge is a named metric that can be set or adjusted
+type Gauge interface {
+	With(label, value string) Gauge
+	Set(value float64)
+	Add(delta float64)
+}
+
+//Histogram is a named metric that observes sample values
+type Histogram interface {
+	With(label, value string) Histogram
+	Observe(value float64)
+}
+
+//Provider abstracts away the choice of stats backend so applications can depend on it
+//instead of a concrete Broker and swap backends (or fan out to several, via
+//MultiProvider) without touching call sites. BrokerProvider (and the statsd/graphite
+//providers built on it) caches handles by name, so repeated calls for the same name
+//return the same handle; PrometheusProvider and MultiProvider return a new handle on
+//every call, which writes to the same underlying collectors for that name. With is a no-op
+//that returns the receiver unchanged on backends that don't support tags.
+type Provider interface {
+	NewCounter(name string) Counter
+	NewGauge(name string) Gauge
+	NewHistogram(name string) Histogram
+	Stop()
+}
+
+//BrokerProvider is a Provider backed directly by a Broker
+type BrokerProvider struct {
+	broker Broker
+
+	mu         sync.Mutex
+	counters   map[string]*brokerCounter
+	gauges     map[string]*brokerGauge
+	histograms map[string]*brokerHistogram
+}
+
+//NewBrokerProvider wraps an existing Broker as a Provider. Stop is a no-op; the caller
+//retains ownership of the Broker's lifecycle.
+func NewBrokerProvider(broker Broker) *BrokerProvider {
+	return &BrokerProvider{
+		broker:     broker,
+		counters:   make(map[string]*brokerCounter),
+		gauges:     make(map[string]*brokerGauge),
+		histograms: make(map[string]*brokerHistogram),
+	}
+}
+
+//NewCounter returns the cached Counter for name, creating it if this is the first call
+func (p *BrokerProvider) NewCounter(name string) Counter {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, ok := p.counters[name]
+	if !ok {
+		c = &brokerCounter{broker: p.broker, name: name}
+		p.counters[name] = c
+	}
+
+	return c
+}
+
+//NewGauge returns the cached Gauge for name, creating it if this is the first call
+func (p *BrokerProvider) NewGauge(name string) Gauge {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	g, ok := p.gauges[name]
+	if !ok {
+		g = &brokerGauge{broker: p.broker, name: name}
+		p.gauges[name] = g
+	}
+
+	return g
+}
+
+//NewHistogram returns the cached Histogram for name, creating it if this is the first call
+func (p *BrokerProvider) NewHistogram(name string) Histogram {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h, ok := p.histograms[name]
+	if !ok {
+		h = &brokerHistogram{broker: p.broker, name: name}
+		p.histograms[name] = h
+	}
+
+	return h
+}
+
+//Stop is a no-op; the caller retains ownership of the wrapped Broker's lifecycle
+func (p *BrokerProvider) Stop() {}
+
+type brokerCounter struct {
+	broker Broker
+	name   string
+	tags   map[string]string
+}
+
+func (c *brokerCounter) With(label, value string) Counter {
+	return &brokerCounter{broker: c.broker, name: c.name, tags: withTag(c.tags, label, value)}
+}
+
+func (c *brokerCounter) Add(delta float64) {
+	c.broker.CountTagged(c.name, int(delta), c.tags)
+}
+
+type brokerGauge struct {
+	broker Broker
+	name   string
+	tags   map[string]string
+}
+
+func (g *brokerGauge) With(label, value string) Gauge {
+	return &brokerGauge{broker: g.broker, name: g.name, tags: withTag(g.tags, label, value)}
+}
+
+func (g *brokerGauge) Set(value float64) {
+	g.broker.GaugeTagged(g.name, int(value), g.tags)
+}
+
+func (g *brokerGauge) Add(delta float64) {
+	g.broker.GaugeTagged(g.name, int(delta), g.tags)
+}
+
+type brokerHistogram struct {
+	broker Broker
+	name   string
+	tags   map[string]string
+}
+
+func (h *brokerHistogram) With(label, value string) Histogram {
+	return &brokerHistogram{broker: h.broker, name: h.name, tags: withTag(h.tags, label, value)}
+}
+
+func (h *brokerHistogram) Observe(value float64) {
+	h.broker.HistogramTagged(h.name, int(value), h.tags)
+}
+
+func withTag(tags map[string]string, label, value string) map[string]string {
+	cp := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		cp[k] = v
+	}
+	cp[label] = value
+
+	return cp
+}
+
+//PrometheusProvider is a Provider that publishes metrics as Prometheus collectors via an
+//underlying PrometheusEndpoint. With is a no-op: the endpoint registers its collectors
+//without const labels, so tags can't be represented.
+type PrometheusProvider struct {
+	endpoint *PrometheusEndpoint
+}
+
+//NewPrometheusProvider wraps a PrometheusEndpoint as a Provider
+func NewPrometheusProvider(endpoint *PrometheusEndpoint) *PrometheusProvider {
+	return &PrometheusProvider{endpoint: endpoint}
+}
+
+func (p *PrometheusProvider) NewCounter(name string) Counter {
+	return &prometheusCounter{endpoint: p.endpoint, name: name}
+}
+
+func (p *PrometheusProvider) NewGauge(name string) Gauge {
+	return &prometheusGauge{endpoint: p.endpoint, name: name}
+}
+
+func (p *PrometheusProvider) NewHistogram(name string) Histogram {
+	return &prometheusHistogram{endpoint: p.endpoint, name: name}
+}
+
+//Stop is a no-op; the caller retains ownership of the underlying registry's lifecycle
+func (p *PrometheusProvider) Stop() {}
+
+type prometheusCounter struct {
+	endpoint *PrometheusEndpoint
+	name     string
+}
+
+func (c *prometheusCounter) With(label, value string) Counter { return c }
+func (c *prometheusCounter) Add(delta float64) {
+	c.endpoint.counterFor(c.name).WithLabelValues().Add(delta)
+}
+
+type prometheusGauge struct {
+	endpoint *PrometheusEndpoint
+	name     string
+}
+
+func (g *prometheusGauge) With(label, value string) Gauge { return g }
+func (g *prometheusGauge) Set(value float64) {
+	g.endpoint.gaugeFor(g.name).WithLabelValues().Set(value)
+}
+func (g *prometheusGauge) Add(delta float64) {
+	g.endpoint.gaugeFor(g.name).WithLabelValues().Add(delta)
+}
+
+type prometheusHistogram struct {
+	endpoint *PrometheusEndpoint
+	name     string
+}
+
+func (h *prometheusHistogram) With(label, value string) Histogram { return h }
+func (h *prometheusHistogram) Observe(value float64) {
+	h.endpoint.histogramFor(h.name).WithLabelValues().Observe(value)
+}
+
+//StatsdProvider is a Provider that ships metrics to statsd via a dedicated Broker
+type StatsdProvider struct {
+	*BrokerProvider
+	broker Broker
+	cancel context.CancelFunc
+}
+
+//NewStatsdProvider starts a private Broker with a single statsd endpoint registered
+//against addr/prefix and wraps it as a Provider
+func NewStatsdProvider(addr, prefix string, bufferSize int) *StatsdProvider {
+	broker := StartBroker(bufferSize)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go StartStatsd(ctx, broker, addr, prefix)
+
+	return &StatsdProvider{BrokerProvider: NewBrokerProvider(broker), broker: broker, cancel: cancel}
+}
+
+//Stop cancels the statsd endpoint and shuts down the private Broker
+func (p *StatsdProvider) Stop() {
+	p.cancel()
+	p.broker.Finish(context.Background())
+}
+
+//GraphiteProvider is a Provider that ships metrics to graphite via a dedicated Broker
+type GraphiteProvider struct {
+	*BrokerProvider
+	broker Broker
+	cancel context.CancelFunc
+}
+
+//NewGraphiteProvider starts a private Broker with a single graphite endpoint registered
+//against g and wraps it as a Provider
+func NewGraphiteProvider(g *graphite.Graphite, bufferSize int) *GraphiteProvider {
+	broker := StartBroker(bufferSize)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go StartGraphite(ctx, broker, g)
+
+	return &GraphiteProvider{BrokerProvider: NewBrokerProvider(broker), broker: broker, cancel: cancel}
+}
+
+//Stop cancels the graphite endpoint and shuts down the private Broker
+func (p *GraphiteProvider) Stop() {
+	p.cancel()
+	p.broker.Finish(context.Background())
+}
+
+//DiscardProvider is a Provider whose metrics discard every observation; useful as a
+//default when stats haven't been configured
+type DiscardProvider struct{}
+
+func (DiscardProvider) NewCounter(name string) Counter     { return discardCounter{} }
+func (DiscardProvider) NewGauge(name string) Gauge         { return discardGauge{} }
+func (DiscardProvider) NewHistogram(name string) Histogram { return discardHistogram{} }
+func (DiscardProvider) Stop()                              {}
+
+type discardCounter struct{}
+
+func (discardCounter) With(label, value string) Counter { return discardCounter{} }
+func (discardCounter) Add(delta float64)                {}
+
+type discardGauge struct{}
+
+func (discardGauge) With(label, value string) Gauge { return discardGauge{} }
+func (discardGauge) Set(value float64)              {}
+func (discardGauge) Add(delta float64)              {}
+
+type discardHistogram struct{}
+
+func (discardHistogram) With(label, value string) Histogram { return discardHistogram{} }
+func (discardHistogram) Observe(value float64)               {}
+
+//MultiProvider fans a single Provider API out to several underlying Providers, e.g. to
+//ship the same metrics to both Prometheus and statsd
+type MultiProvider []Provider
+
+func (m MultiProvider) NewCounter(name string) Counter {
+	counters := make(multiCounter, len(m))
+	for i, p := range m {
+		counters[i] = p.NewCounter(name)
+	}
+
+	return counters
+}
+
+func (m MultiProvider) NewGauge(name string) Gauge {
+	gauges := make(multiGauge, len(m))
+	for i, p := range m {
+		gauges[i] = p.NewGauge(name)
+	}
+
+	return gauges
+}
+
+func (m MultiProvider) NewHistogram(name string) Histogram {
+	histograms := make(multiHistogram, len(m))
+	for i, p := range m {
+		histograms[i] = p.NewHistogram(name)
+	}
+
+	return histograms
+}
+
+//Stop stops every underlying Provider
+func (m MultiProvider) Stop() {
+	for _, p := range m {
+		p.Stop()
+	}
+}
+
+type multiCounter []Counter
+
+func (m multiCounter) With(label, value string) Counter {
+	next := make(multiCounter, len(m))
+	for i, c := range m {
+		next[i] = c.With(label, value)
+	}
+
+	return next
+}
+
+func (m multiCounter) Add(delta float64) {
+	for _, c := range m {
+		c.Add(delta)
+	}
+}
+
+type multiGauge []Gauge
+
+func (m multiGauge) With(label, value string) Gauge {
+	next := make(multiGauge, len(m))
+	for i, g := range m {
+		next[i] = g.With(label, value)
+	}
+
+	return next
+}
+
+func (m multiGauge) Set(value float64) {
+	for _, g := range m {
+		g.Set(value)
+	}
+}
+
+func (m multiGauge) Add(delta float64) {
+	for _, g := range m {
+		g.Add(delta)
+	}
+}
+
+type multiHistogram []Histogram
+
+func (m multiHistogram) With(label, value string) Histogram {
+	next := make(multiHistogram, len(m))
+	for i, h := range m {
+		next[i] = h.With(label, value)
+	}
+
+	return next
+}
+
+func (m multiHistogram) Observe(value float64) {
+	for _, h := range m {
+		h.Observe(value)
+	}
+}
@@ -0,0 +1,40 @@
+package stats
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"context"
+	"runtime"
+	"time"
+)
+
+//ReportRuntimeStats gauges runtime.NumGoroutine and memory stats from
+//runtime.ReadMemStats onto DefaultBroker under the "runtime." prefix every interval,
+//until ctx is cancelled.
+func ReportRuntimeStats(ctx context.Context, interval time.Duration) error {
+	go runRuntimeStats(ctx, interval)
+	return nil
+}
+
+func runRuntimeStats(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var mem runtime.MemStats
+
+	for {
+		select {
+		case <-ticker.C:
+			runtime.ReadMemStats(&mem)
+
+			DefaultBroker.Gauge("runtime.goroutines", runtime.NumGoroutine())
+			DefaultBroker.Gauge("runtime.mem.alloc", int(mem.Alloc))
+			DefaultBroker.Gauge("runtime.mem.heap_objects", int(mem.HeapObjects))
+			DefaultBroker.Gauge("runtime.mem.num_gc", int(mem.NumGC))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
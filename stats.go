@@ -83,6 +83,38 @@ type endpoint chan<- interface{}
 
 type poison chan<- error
 
+//count is the datum sent by Count
+type count struct {
+	Name  string
+	Value int
+	Tags  map[string]string
+}
+
+//gauge is the datum sent by Gauge
+type gauge struct {
+	Name  string
+	Value int
+	Tags  map[string]string
+}
+
+//biggauge is the datum sent by BigGauge
+type biggauge struct {
+	Name  string
+	Value uint64
+}
+
+//timing is the datum sent by Timing
+type timing struct {
+	Name  string
+	Value int
+	Tags  map[string]string
+}
+
+//event is the datum sent by GraphiteEvent
+type event struct {
+	*graphite.Event
+}
+
 //ErrActivityBufferFull is returned if the brokers buffer is full when attempting to register an endpoint or stop the broker
 var ErrActivityBufferFull = fmt.Errorf("stats activity buffer full")
 
@@ -126,7 +158,12 @@ func (s Broker) Send(datum interface{}) {
 
 //Count sends a count value for the given name
 func (s Broker) Count(name string, value int) {
-	s.Send(&count{Name: name, Value: value})
+	s.CountTagged(name, value, nil)
+}
+
+//CountTagged sends a count value for the given name along with a set of tags
+func (s Broker) CountTagged(name string, value int, tags map[string]string) {
+	s.Send(&count{Name: name, Value: value, Tags: tags})
 }
 
 //Incr increments a count by 1
@@ -141,7 +178,12 @@ func (s Broker) BigGauge(name string, value uint64) {
 
 //Gauge sends a gauge value for the given name
 func (s Broker) Gauge(name string, value int) {
-	s.Send(&gauge{Name: name, Value: value})
+	s.GaugeTagged(name, value, nil)
+}
+
+//GaugeTagged sends a gauge value for the given name along with a set of tags
+func (s Broker) GaugeTagged(name string, value int, tags map[string]string) {
+	s.Send(&gauge{Name: name, Value: value, Tags: tags})
 }
 
 //On sends a 1 gauge
@@ -154,9 +196,26 @@ func (s Broker) Off(name string) {
 	s.Gauge(name, 0)
 }
 
-//Timing sends a timing value for the given name
+//Histogram sends a value to be aggregated into a windowed histogram. Endpoints that
+//support it emit derived .p50/.p90/.p95/.p99/.min/.max/.count/.avg stats on their own
+//flush interval rather than treating every sample independently.
+func (s Broker) Histogram(name string, value int) {
+	s.HistogramTagged(name, value, nil)
+}
+
+//HistogramTagged is Histogram with a set of tags attached
+func (s Broker) HistogramTagged(name string, value int, tags map[string]string) {
+	s.Send(&timing{Name: name, Value: value, Tags: tags})
+}
+
+//Timing sends a timing value for the given name. It is an alias for Histogram.
 func (s Broker) Timing(name string, value int) {
-	s.Send(&timing{Name: name, Value: value})
+	s.Histogram(name, value)
+}
+
+//TimingTagged sends a timing value for the given name along with a set of tags
+func (s Broker) TimingTagged(name string, value int, tags map[string]string) {
+	s.HistogramTagged(name, value, tags)
 }
 
 //TimingDuration sends a timing value for the duration provided
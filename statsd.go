@@ -0,0 +1,126 @@
+package stats
+
+//Copyright 2016 MediaMath <http://www.mediamath.com>.  All rights reserved.
+//Use of this source code is governed by a BSD-style
+//license that can be found in the LICENSE file.
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/varun06/stats/internal/conn"
+)
+
+//statsdQueueSize bounds how many pending statsd lines are buffered while the UDP
+//socket is being redialed before new ones start getting dropped
+const statsdQueueSize = 1000
+
+//statsdHistogramWindow is how often each stat's WindowedHistogram rotates and its
+//derived quantile stats are flushed
+const statsdHistogramWindow = time.Minute
+
+//statsdHistogramWindows is the number of windows each stat's WindowedHistogram keeps
+//live at once, i.e. how many statsdHistogramWindow periods a quantile is computed over
+const statsdHistogramWindows = 5
+
+//StartStatsd registers an Endpoint that renders count/gauge datums as statsd protocol
+//lines ("name:value|c", "|g", dogstatsd-style "|#k:v" tags) and writes them over UDP to
+//addr with prefix prepended to every name. Writes go through a conn.Manager so a bad
+//socket is silently redialed on a backoff schedule instead of wedging the endpoint.
+//Timing datums are buffered into a per-name WindowedHistogram and only the derived
+//.p50/.p90/.p95/.p99/.min/.max/.avg/.count gauges are flushed, rather than a "|ms" line
+//per sample.
+func StartStatsd(ctx context.Context, broker Broker, addr string, prefix string) error {
+	dial := func() (conn.Conn, error) {
+		c, err := net.Dial("udp", addr)
+		if err != nil {
+			return nil, err
+		}
+
+		return c, nil
+	}
+
+	m := conn.NewManager(dial, statsdQueueSize, nil)
+	go ReportConnStats(ctx, broker, "statsd", m, connStatsInterval)
+
+	return broker.RegisterEndpoint(func(events <-chan interface{}) {
+		runStatsd(ctx, m, prefix, events)
+	})
+}
+
+func runStatsd(ctx context.Context, m *conn.Manager, prefix string, events <-chan interface{}) {
+	defer m.Close()
+
+	histograms := make(map[string]*WindowedHistogram)
+	ticker := time.NewTicker(statsdHistogramWindow)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case act, ok := <-events:
+			if !ok {
+				return
+			}
+
+			switch a := act.(type) {
+			case *timing:
+				h, ok := histograms[a.Name]
+				if !ok {
+					h = NewWindowedHistogram(statsdHistogramWindows, 0)
+					histograms[a.Name] = h
+				}
+				h.Record(a.Value)
+			default:
+				if line := statsdLine(prefix, act); line != "" {
+					m.Write([]byte(line))
+				}
+			}
+		case <-ticker.C:
+			for name, h := range histograms {
+				for _, stat := range h.QuantileStats(name) {
+					m.Write([]byte(formatStatsd(prefix, stat.Name, int(stat.Value), "g", nil)))
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func statsdLine(prefix string, act interface{}) string {
+	switch a := act.(type) {
+	case *count:
+		return formatStatsd(prefix, a.Name, a.Value, "c", a.Tags)
+	case *gauge:
+		return formatStatsd(prefix, a.Name, a.Value, "g", a.Tags)
+	case *biggauge:
+		return formatStatsd(prefix, a.Name, int(a.Value), "g", nil)
+	default:
+		return ""
+	}
+}
+
+func formatStatsd(prefix, name string, value int, kind string, tags map[string]string) string {
+	full := name
+	if prefix != "" {
+		full = prefix + "." + name
+	}
+
+	line := fmt.Sprintf("%s:%d|%s", full, value, kind)
+
+	if len(tags) > 0 {
+		pairs := make([]string, 0, len(tags))
+		for k, v := range tags {
+			pairs = append(pairs, k+":"+v)
+		}
+		sort.Strings(pairs)
+
+		line += "|#" + strings.Join(pairs, ",")
+	}
+
+	return line
+}